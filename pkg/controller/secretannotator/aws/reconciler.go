@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -10,6 +11,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -19,6 +21,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/hashicorp/go-multierror"
 
 	ccaws "github.com/openshift/cloud-credential-operator/pkg/aws"
 	"github.com/openshift/cloud-credential-operator/pkg/controller/secretannotator/constants"
@@ -26,23 +32,62 @@ import (
 )
 
 const (
-	// TODO: dynamically detect which environment we're running on
-	AWSCloudCredSecretName = "aws-creds"
+	AwsAccessKeyName            = "aws_access_key_id"
+	AwsSecretAccessKeyName      = "aws_secret_access_key"
+	AwsSessionTokenName         = "aws_session_token"
+	AwsCredsExpiryName          = "aws_credentials_expiry"
+	AwsRoleARNName              = "role_arn"
+	AwsWebIdentityTokenFileName = "web_identity_token_file"
 
-	AwsAccessKeyName       = "aws_access_key_id"
-	AwsSecretAccessKeyName = "aws_secret_access_key"
+	// PassthroughSTSAnnotation is used whenever the creds are temporary STS
+	// credentials (e.g. obtained via AssumeRole, AssumeRoleWithSAML, or
+	// AssumeRoleWithWebIdentity) that are sufficient for passthrough, but
+	// cannot be used for minting since they lack IAM user creation rights.
+	PassthroughSTSAnnotation = "passthrough-sts"
+
+	// WorkloadIdentityAnnotation is used whenever the creds are an IRSA
+	// web-identity federated identity that has no long-lived access key at
+	// all, so minting is never attempted.
+	WorkloadIdentityAnnotation = "workload-identity"
+
+	// ExpiryAnnotation carries the RFC3339 timestamp at which the underlying
+	// cloud creds are known to expire, so that other controllers can trigger
+	// a re-reconcile ahead of expiration.
+	ExpiryAnnotation = "cloudcredential.openshift.io/credentials-expiry"
+
+	// LastValidationErrorsAnnotation carries the aggregated, newline-separated
+	// set of reasons (one per failed sub-check) behind the most recent
+	// validation result, so admins can see every contributing cause at once
+	// instead of iteratively fixing one at a time.
+	LastValidationErrorsAnnotation = "cloudcredential.openshift.io/last-validation-errors"
+
+	// LastValidationTimeAnnotation carries the RFC3339 timestamp of the most
+	// recent validation attempt.
+	LastValidationTimeAnnotation = "cloudcredential.openshift.io/last-validation-time"
+
+	// ReasonSecretKeyMissing indicates the cloud cred secret was missing a
+	// required key.
+	ReasonSecretKeyMissing = "SecretKeyMissing"
+
+	// ReasonMintAPIDenied indicates the mint-capability probe failed.
+	ReasonMintAPIDenied = "MintAPIDenied"
+
+	// ReasonPassthroughSimulateFailed indicates the passthrough-capability
+	// permission simulation failed.
+	ReasonPassthroughSimulateFailed = "PassthroughSimulateFailed"
 )
 
 func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
 	return &ReconcileCloudCredSecret{
-		Client:           mgr.GetClient(),
-		Logger:           log.WithField("controller", constants.ControllerName),
-		AWSClientBuilder: ccaws.NewClientWithCreds,
+		Client:                       mgr.GetClient(),
+		Logger:                       log.WithField("controller", constants.ControllerName),
+		AWSClientBuilder:             ccaws.NewClientWithCreds,
+		AWSClientBuilderFromProvider: ccaws.NewClientFromProvider,
 	}
 }
 
-func cloudCredSecretObjectCheck(secret metav1.Object) bool {
-	return secret.GetNamespace() == constants.CloudCredSecretNamespace && secret.GetName() == AWSCloudCredSecretName
+func cloudCredSecretObjectCheck(secret metav1.Object, rootSecret types.NamespacedName) bool {
+	return secret.GetNamespace() == rootSecret.Namespace && secret.GetName() == rootSecret.Name
 }
 
 func Add(mgr manager.Manager, r reconcile.Reconciler) error {
@@ -52,16 +97,24 @@ func Add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	rootSecret, kind, err := utils.ResolveRootCredSecret(mgr.GetClient())
+	if err != nil {
+		return fmt.Errorf("error resolving root cred secret: %v", err)
+	}
+	if err := utils.RequireSupportedPlatform(kind, utils.AWSPlatformKind); err != nil {
+		return fmt.Errorf("secretannotator/aws: %v", err)
+	}
+
 	// Watch for changes to cluster cloud secret
 	p := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			return cloudCredSecretObjectCheck(e.MetaNew)
+			return cloudCredSecretObjectCheck(e.MetaNew, rootSecret)
 		},
 		CreateFunc: func(e event.CreateEvent) bool {
-			return cloudCredSecretObjectCheck(e.Meta)
+			return cloudCredSecretObjectCheck(e.Meta, rootSecret)
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
-			return cloudCredSecretObjectCheck(e.Meta)
+			return cloudCredSecretObjectCheck(e.Meta, rootSecret)
 		},
 	}
 	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForObject{}, p)
@@ -77,12 +130,20 @@ type ReconcileCloudCredSecret struct {
 	client.Client
 	Logger           log.FieldLogger
 	AWSClientBuilder func(creds *credentials.Value, infraName string) (ccaws.Client, error)
+	// AWSClientBuilderFromProvider builds an AWS client from a credentials.Provider
+	// rather than a static credentials.Value, so that federated/assumed-role
+	// identities (e.g. IRSA web-identity) can be validated without ever
+	// materializing a long-lived access key.
+	AWSClientBuilderFromProvider func(provider credentials.Provider, infraName string) (ccaws.Client, error)
 }
 
 // Reconcile will annotate the cloud cred secret to indicate the capabilities of the cred's capabilities:
 // 1) 'mint' for indicating that the creds can be used to create new sub-creds
 // 2) 'passthrough' for indicating that the creds are capable enough for other components to reuse the creds as-is
-// 3) 'insufficient' for indicating that the creds are not usable for the cluster
+// 3) 'passthrough-sts' like 'passthrough', but for temporary STS creds which cannot be used for minting
+// 4) 'workload-identity' like 'passthrough-sts', but for a federated identity (e.g. IRSA) with no access key at all
+// 5) 'insufficient' for indicating that the creds are not usable for the cluster
+// If the secret carries a credentials expiry, Reconcile is requeued for that expiry itself; no lead time is subtracted from it.
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;update
 func (r *ReconcileCloudCredSecret) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	r.Logger.Info("validating cloud cred secret")
@@ -94,78 +155,231 @@ func (r *ReconcileCloudCredSecret) Reconcile(request reconcile.Request) (reconci
 		return reconcile.Result{}, err
 	}
 
-	err = r.validateCloudCredsSecret(secret)
+	result, err := r.validateCloudCredsSecret(secret)
 	if err != nil {
 		r.Logger.Errorf("error while validating cloud credentials: %v", err)
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	return result, nil
 }
 
-func (r *ReconcileCloudCredSecret) validateCloudCredsSecret(secret *corev1.Secret) error {
-	accessKey, ok := secret.Data[AwsAccessKeyName]
-	if !ok {
-		r.Logger.Errorf("Couldn't fetch key containing AWS_ACCESS_KEY_ID from cloud cred secret")
-		return r.updateSecretAnnotations(secret, constants.InsufficientAnnotation)
+func (r *ReconcileCloudCredSecret) validateCloudCredsSecret(secret *corev1.Secret) (reconcile.Result, error) {
+	var errs *multierror.Error
+
+	if roleARN, tokenFile, ok := webIdentityShape(secret); ok {
+		return r.validateWebIdentityCredsSecret(secret, roleARN, tokenFile)
 	}
 
-	secretKey, ok := secret.Data[AwsSecretAccessKeyName]
-	if !ok {
-		r.Logger.Errorf("Couldn't fetch key containing AWS_SECRET_ACCESS_KEY from cloud cred secret")
-		return r.updateSecretAnnotations(secret, constants.InsufficientAnnotation)
+	if missing := utils.MissingRequiredKeys(secret, utils.AWSPlatformKind); len(missing) > 0 {
+		for _, key := range missing {
+			r.Logger.Errorf("Couldn't fetch key %s from cloud cred secret", key)
+			errs = multierror.Append(errs, fmt.Errorf("%s: missing key %s", ReasonSecretKeyMissing, key))
+		}
+		return reconcile.Result{}, r.finalizeSecretAnnotations(secret, constants.InsufficientAnnotation, errs)
 	}
 
+	accessKey := secret.Data[AwsAccessKeyName]
+	secretKey := secret.Data[AwsSecretAccessKeyName]
+
+	// aws_session_token is optional, and only present when the creds are
+	// temporary STS creds (AssumeRole/AssumeRoleWithSAML/AssumeRoleWithWebIdentity).
+	sessionToken, isSTS := secret.Data[AwsSessionTokenName]
+
 	infraName, err := utils.LoadInfrastructureName(r.Client, r.Logger)
 	if err != nil {
-		return err
+		return reconcile.Result{}, err
 	}
 	creds := &credentials.Value{
 		AccessKeyID:     string(accessKey),
 		SecretAccessKey: string(secretKey),
+		SessionToken:    string(sessionToken),
 	}
 	awsClient, err := r.AWSClientBuilder(creds, infraName)
 	if err != nil {
-		return fmt.Errorf("error creating aws client: %v", err)
+		return reconcile.Result{}, fmt.Errorf("error creating aws client: %v", err)
+	}
+
+	requeueAfter, err := r.expiryRequeueAfter(secret)
+	if err != nil {
+		r.Logger.WithError(err).Warning("unable to determine cloud creds expiry")
+	}
+
+	if isSTS {
+		// STS creds are temporary and cannot be used to mint new IAM users,
+		// so only check whether they are sufficient for passthrough.
+		cloudCheckResult, err := ccaws.CheckCloudCredPassthrough(awsClient, r.Logger)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonPassthroughSimulateFailed, err))
+		}
+
+		if cloudCheckResult {
+			r.Logger.Info("Verified STS cloud creds can be used as-is (passthrough)")
+			return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, PassthroughSTSAnnotation, errs)
+		}
+
+		r.Logger.Warning("STS cloud creds unable to be used for passthrough")
+		return reconcile.Result{}, r.finalizeAndPropagate(secret, constants.InsufficientAnnotation, errs)
 	}
 
 	// Can we mint new creds?
 	cloudCheckResult, err := ccaws.CheckCloudCredCreation(awsClient, r.Logger)
 	if err != nil {
-		r.updateSecretAnnotations(secret, constants.InsufficientAnnotation)
-		return fmt.Errorf("failed checking create cloud creds: %v", err)
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonMintAPIDenied, err))
 	}
 
 	if cloudCheckResult {
 		r.Logger.Info("Verified cloud creds can be used for minting new creds")
-		return r.updateSecretAnnotations(secret, constants.MintAnnotation)
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, constants.MintAnnotation, errs)
 	}
 
 	// Else, can we just pass through the current creds?
 	cloudCheckResult, err = ccaws.CheckCloudCredPassthrough(awsClient, r.Logger)
 	if err != nil {
-		r.updateSecretAnnotations(secret, constants.InsufficientAnnotation)
-		return fmt.Errorf("failed checking passthrough cloud creds: %v", err)
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonPassthroughSimulateFailed, err))
 	}
 
 	if cloudCheckResult {
 		r.Logger.Info("Verified cloud creds can be used as-is (passthrough)")
-		return r.updateSecretAnnotations(secret, constants.PassthroughAnnotation)
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, constants.PassthroughAnnotation, errs)
 	}
 
 	// Else, these creds aren't presently useful
 	r.Logger.Warning("Cloud creds unable to be used for either minting or passthrough")
-	return r.updateSecretAnnotations(secret, constants.InsufficientAnnotation)
+	return reconcile.Result{}, r.finalizeAndPropagate(secret, constants.InsufficientAnnotation, errs)
 }
 
-func (r *ReconcileCloudCredSecret) updateSecretAnnotations(secret *corev1.Secret, value string) error {
+// webIdentityShape reports whether the secret carries an AWS IRSA web-identity
+// shape (role_arn + web_identity_token_file) rather than a static access key pair.
+func webIdentityShape(secret *corev1.Secret) (roleARN, tokenFile string, ok bool) {
+	rawRoleARN, hasRoleARN := secret.Data[AwsRoleARNName]
+	rawTokenFile, hasTokenFile := secret.Data[AwsWebIdentityTokenFileName]
+	if !hasRoleARN || !hasTokenFile {
+		return "", "", false
+	}
+	return string(rawRoleARN), string(rawTokenFile), true
+}
+
+// validateWebIdentityCredsSecret validates creds assumed via AWS IRSA
+// (stscreds.NewWebIdentityRoleProvider). Minting is never attempted since a
+// web-identity session cannot create new IAM users; only the passthrough
+// permission simulation is run against the assumed role.
+func (r *ReconcileCloudCredSecret) validateWebIdentityCredsSecret(secret *corev1.Secret, roleARN, tokenFile string) (reconcile.Result, error) {
+	var errs *multierror.Error
+
+	infraName, err := utils.LoadInfrastructureName(r.Client, r.Logger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error creating aws session: %v", err)
+	}
+	provider := stscreds.NewWebIdentityRoleProvider(sts.New(sess), roleARN, "cloud-credential-operator", tokenFile)
+
+	// Retrieve up front (rather than leaving it to the first AWS API call) so
+	// that the assumed-role session's own expiration, not an unrelated
+	// aws_credentials_expiry secret field, drives the requeue.
+	if _, err := provider.Retrieve(); err != nil {
+		return reconcile.Result{}, fmt.Errorf("error retrieving web identity creds: %v", err)
+	}
+	requeueAfter := requeueAfterOrImmediate(time.Until(provider.ExpiresAt()))
+	r.recordExpiryAnnotation(secret, provider.ExpiresAt())
+
+	awsClient, err := r.AWSClientBuilderFromProvider(provider, infraName)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error creating aws client from web identity provider: %v", err)
+	}
+
+	cloudCheckResult, err := ccaws.CheckCloudCredPassthrough(awsClient, r.Logger)
+	if err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonPassthroughSimulateFailed, err))
+	}
+
+	if cloudCheckResult {
+		r.Logger.Info("Verified web identity creds can be used as-is (passthrough)")
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, WorkloadIdentityAnnotation, errs)
+	}
+
+	r.Logger.Warning("Web identity creds unable to be used for passthrough")
+	return reconcile.Result{}, r.finalizeAndPropagate(secret, constants.InsufficientAnnotation, errs)
+}
+
+// expiryRequeueAfter reads the optional aws_credentials_expiry field from the
+// secret, records it as an annotation so other controllers can observe it,
+// and returns the duration until that expiry so the caller can requeue the
+// Reconcile for the moment the creds expire.
+func (r *ReconcileCloudCredSecret) expiryRequeueAfter(secret *corev1.Secret) (time.Duration, error) {
+	rawExpiry, ok := secret.Data[AwsCredsExpiryName]
+	if !ok {
+		return 0, nil
+	}
+
+	expiry, err := time.Parse(time.RFC3339, string(rawExpiry))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q: %v", AwsCredsExpiryName, string(rawExpiry), err)
+	}
+
+	r.recordExpiryAnnotation(secret, expiry)
+	return requeueAfterOrImmediate(time.Until(expiry)), nil
+}
+
+// recordExpiryAnnotation sets ExpiryAnnotation on secret to expiry, so other
+// controllers can observe the cloud creds' known expiration regardless of
+// which validation path (static expiry field, assumed-role session) derived it.
+func (r *ReconcileCloudCredSecret) recordExpiryAnnotation(secret *corev1.Secret, expiry time.Time) {
+	annotations := secret.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ExpiryAnnotation] = expiry.UTC().Format(time.RFC3339)
+	secret.SetAnnotations(annotations)
+}
+
+// requeueAfterOrImmediate clamps d to a minimal positive duration when it is
+// zero or negative. controller-runtime's workqueue only schedules a delayed
+// AddAfter when RequeueAfter > 0, so creds whose expiry is already in the
+// past must still get a positive value here, or the expiry-driven reconcile
+// never fires.
+func requeueAfterOrImmediate(d time.Duration) time.Duration {
+	if d <= 0 {
+		return time.Second
+	}
+	return d
+}
+
+// finalizeSecretAnnotations sets the mode annotation, along with the
+// aggregated validation errors (if any) and the time of this validation
+// attempt, then persists the secret in a single update.
+func (r *ReconcileCloudCredSecret) finalizeSecretAnnotations(secret *corev1.Secret, mode string, errs *multierror.Error) error {
 	secretAnnotations := secret.GetAnnotations()
 	if secretAnnotations == nil {
 		secretAnnotations = map[string]string{}
 	}
 
-	secretAnnotations[constants.AnnotationKey] = value
+	secretAnnotations[constants.AnnotationKey] = mode
+	if err := errs.ErrorOrNil(); err != nil {
+		secretAnnotations[LastValidationErrorsAnnotation] = err.Error()
+	} else {
+		delete(secretAnnotations, LastValidationErrorsAnnotation)
+	}
+	secretAnnotations[LastValidationTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
 	secret.SetAnnotations(secretAnnotations)
 
 	return r.Update(context.Background(), secret)
 }
+
+// finalizeAndPropagate behaves like finalizeSecretAnnotations, but also
+// surfaces any aggregated check errors back to the caller once the secret has
+// been annotated, so that Reconcile returns a real error and controller-runtime
+// requeues with exponential backoff. Without this, a transient failure (e.g.
+// throttling during the mint/passthrough probes) would be recorded in the
+// last-validation-errors annotation and then forgotten until an unrelated
+// secret-update event happened to refire the watch.
+func (r *ReconcileCloudCredSecret) finalizeAndPropagate(secret *corev1.Secret, mode string, errs *multierror.Error) error {
+	if err := r.finalizeSecretAnnotations(secret, mode, errs); err != nil {
+		return err
+	}
+	return errs.ErrorOrNil()
+}
@@ -18,7 +18,12 @@ package secretannotator
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -26,6 +31,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -35,18 +41,19 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/hashicorp/go-multierror"
 
 	ccaws "github.com/openshift/cloud-credential-operator/pkg/aws"
+	ccazure "github.com/openshift/cloud-credential-operator/pkg/azure"
 	"github.com/openshift/cloud-credential-operator/pkg/controller/utils"
 )
 
 const (
 	controllerName = "secretannotator"
 
-	// TODO: dynamically detect which environment we're running on
-	CloudCredSecretName      = "aws-creds"
-	CloudCredSecretNamespace = "kube-system"
-
 	AnnotationKey = "cloudcredential.openshift.io/mode"
 
 	// MintAnnottation is used whenever it is determined that the cloud creds
@@ -63,8 +70,28 @@ const (
 	// sufficient permissions for cluster runtime.
 	InsufficientAnnotation = "insufficient"
 
-	AwsAccessKeyName       = "aws_access_key_id"
-	AwsSecretAccessKeyName = "aws_secret_access_key"
+	// PassthroughSTSAnnotation is used whenever the creds are temporary STS
+	// credentials (e.g. obtained via AssumeRole, AssumeRoleWithSAML, or
+	// AssumeRoleWithWebIdentity) that are sufficient for passthrough, but
+	// cannot be used for minting since they lack IAM user creation rights.
+	PassthroughSTSAnnotation = "passthrough-sts"
+
+	// ExpiryAnnotation carries the RFC3339 timestamp at which the underlying
+	// cloud creds are known to expire, so that other controllers can trigger
+	// a re-reconcile ahead of expiration.
+	ExpiryAnnotation = "cloudcredential.openshift.io/credentials-expiry"
+
+	// WorkloadIdentityAnnotation is used whenever the creds are a federated
+	// identity (AWS IRSA web-identity token, Azure AD Workload Identity) that
+	// has no long-lived secret/access-key pair to mint new creds from.
+	WorkloadIdentityAnnotation = "workload-identity"
+
+	AwsAccessKeyName            = "aws_access_key_id"
+	AwsSecretAccessKeyName      = "aws_secret_access_key"
+	AwsSessionTokenName         = "aws_session_token"
+	AwsCredsExpiryName          = "aws_credentials_expiry"
+	AwsRoleARNName              = "role_arn"
+	AwsWebIdentityTokenFileName = "web_identity_token_file"
 
 	AzureClientID       = "azure_client_id"
 	AzureClientSecret   = "azure_client_secret"
@@ -73,6 +100,39 @@ const (
 	AzureResourcePrefix = "azure_resource_prefix"
 	AzureSubscriptionID = "azure_subscription_id"
 	AzureTenantID       = "azure_tenant_id"
+
+	// AzureCloudName is optional, and identifies the sovereign Azure cloud
+	// environment (AzurePublicCloud, AzureUSGovernmentCloud, AzureChinaCloud,
+	// AzureGermanCloud) the creds belong to. When absent, it is derived from
+	// the cluster's Infrastructure.Status.PlatformStatus.Azure.CloudName via
+	// utils.LoadInfrastructureAzureCloudName.
+	AzureCloudName = "azure_cloud_name"
+
+	// AzureFederatedTokenFile is the path to the projected federated token
+	// used by Azure AD Workload Identity, paired with AzureClientID and
+	// AzureTenantID instead of a client secret.
+	AzureFederatedTokenFile = "azure_federated_token_file"
+
+	// LastValidationErrorsAnnotation carries the aggregated, newline-separated
+	// set of reasons (one per failed sub-check) behind the most recent
+	// validation result, so admins can see every contributing cause at once
+	// instead of iteratively fixing one at a time.
+	LastValidationErrorsAnnotation = "cloudcredential.openshift.io/last-validation-errors"
+
+	// LastValidationTimeAnnotation carries the RFC3339 timestamp of the most
+	// recent validation attempt.
+	LastValidationTimeAnnotation = "cloudcredential.openshift.io/last-validation-time"
+
+	// ReasonSecretKeyMissing indicates the cloud cred secret was missing a
+	// required key.
+	ReasonSecretKeyMissing = "SecretKeyMissing"
+
+	// ReasonMintAPIDenied indicates the mint-capability probe failed.
+	ReasonMintAPIDenied = "MintAPIDenied"
+
+	// ReasonPassthroughSimulateFailed indicates the passthrough-capability
+	// permission simulation failed.
+	ReasonPassthroughSimulateFailed = "PassthroughSimulateFailed"
 )
 
 func Add(mgr manager.Manager) error {
@@ -81,17 +141,17 @@ func Add(mgr manager.Manager) error {
 
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 	return &ReconcileCloudCredSecret{
-		Client:           mgr.GetClient(),
-		logger:           log.WithField("controller", controllerName),
-		AWSClientBuilder: ccaws.NewClient,
+		Client:                                 mgr.GetClient(),
+		logger:                                 log.WithField("controller", controllerName),
+		AWSClientBuilder:                       ccaws.NewClient,
+		AWSClientBuilderFromProvider:           ccaws.NewClientFromProvider,
+		AzureClientBuilder:                     ccazure.NewClient,
+		AzureClientBuilderFromWorkloadIdentity: ccazure.NewClientFromWorkloadIdentity,
 	}
 }
 
-func cloudCredSecretObjectCheck(secret metav1.Object) bool {
-	if secret.GetNamespace() == CloudCredSecretNamespace && secret.GetName() == CloudCredSecretName {
-		return true
-	}
-	return false
+func cloudCredSecretObjectCheck(secret metav1.Object, rootSecret types.NamespacedName) bool {
+	return secret.GetNamespace() == rootSecret.Namespace && secret.GetName() == rootSecret.Name
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
@@ -101,16 +161,24 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	rootSecret, kind, err := utils.ResolveRootCredSecret(mgr.GetClient())
+	if err != nil {
+		return fmt.Errorf("error resolving root cred secret: %v", err)
+	}
+	if err := utils.RequireSupportedPlatform(kind, utils.AWSPlatformKind, utils.AzurePlatformKind); err != nil {
+		return err
+	}
+
 	// Watch for changes to cluster cloud secret
 	p := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			return cloudCredSecretObjectCheck(e.MetaNew)
+			return cloudCredSecretObjectCheck(e.MetaNew, rootSecret)
 		},
 		CreateFunc: func(e event.CreateEvent) bool {
-			return cloudCredSecretObjectCheck(e.Meta)
+			return cloudCredSecretObjectCheck(e.Meta, rootSecret)
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
-			return cloudCredSecretObjectCheck(e.Meta)
+			return cloudCredSecretObjectCheck(e.Meta, rootSecret)
 		},
 	}
 	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForObject{}, p)
@@ -126,12 +194,29 @@ type ReconcileCloudCredSecret struct {
 	client.Client
 	logger           log.FieldLogger
 	AWSClientBuilder func(creds *credentials.Value, infraName string) (ccaws.Client, error)
+	// AWSClientBuilderFromProvider builds an AWS client from a credentials.Provider
+	// rather than a static credentials.Value, so that federated/assumed-role
+	// identities (e.g. IRSA web-identity) can be validated without ever
+	// materializing a long-lived access key.
+	AWSClientBuilderFromProvider func(provider credentials.Provider, infraName string) (ccaws.Client, error)
+	// AzureClientBuilder builds an Azure client scoped to the given
+	// application creds, subscription, and sovereign cloud environment
+	// (AzurePublicCloud, AzureUSGovernmentCloud, AzureChinaCloud, AzureGermanCloud).
+	AzureClientBuilder func(clientID, clientSecret, tenantID, subscriptionID, cloudName, infraName string) (ccazure.Client, error)
+	// AzureClientBuilderFromWorkloadIdentity builds an Azure client from a
+	// federated workload-identity token file rather than a client secret, so
+	// that Azure AD Workload Identity creds can be validated without ever
+	// materializing a long-lived client secret.
+	AzureClientBuilderFromWorkloadIdentity func(clientID, tenantID, subscriptionID, tokenFile, cloudName, infraName string) (ccazure.Client, error)
 }
 
 // Reconcile will annotate the cloud cred secret to indicate the capabilities of the cred's capabilities:
 // 1) 'mint' for indicating that the creds can be used to create new sub-creds
 // 2) 'passthrough' for indicating that the creds are capable enough for other components to reuse the creds as-is
-// 3) 'insufficient' for indicating that the creds are not usable for the cluster
+// 3) 'passthrough-sts' like 'passthrough', but for temporary STS creds which cannot be used for minting
+// 4) 'workload-identity' like 'passthrough-sts', but for a federated identity (e.g. IRSA) with no access key at all
+// 5) 'insufficient' for indicating that the creds are not usable for the cluster
+// If the secret carries a credentials expiry, Reconcile is requeued for that expiry itself; no lead time is subtracted from it.
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;update
 func (r *ReconcileCloudCredSecret) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	r.logger.Info("validating cloud cred secret")
@@ -143,79 +228,409 @@ func (r *ReconcileCloudCredSecret) Reconcile(request reconcile.Request) (reconci
 		return reconcile.Result{}, err
 	}
 
-	err = r.validateCloudCredsSecret(secret)
+	result, err := r.validateCloudCredsSecret(secret)
 	if err != nil {
 		r.logger.Errorf("error while validating cloud credentials: %v", err)
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	return result, nil
 }
 
-func (r *ReconcileCloudCredSecret) validateCloudCredsSecret(secret *corev1.Secret) error {
+func (r *ReconcileCloudCredSecret) validateCloudCredsSecret(secret *corev1.Secret) (reconcile.Result, error) {
 
-	accessKey, ok := secret.Data[AwsAccessKeyName]
-	if !ok {
-		r.logger.Errorf("Couldn't fetch key containing AWS_ACCESS_KEY_ID from cloud cred secret")
-		return r.updateSecretAnnotations(secret, InsufficientAnnotation)
+	var errs *multierror.Error
+
+	if clientID, tenantID, tokenFile, ok := azureWorkloadIdentityShape(secret); ok {
+		return r.validateAzureWorkloadIdentityCredsSecret(secret, clientID, tenantID, tokenFile)
 	}
 
-	secretKey, ok := secret.Data[AwsSecretAccessKeyName]
-	if !ok {
-		r.logger.Errorf("Couldn't fetch key containing AWS_SECRET_ACCESS_KEY from cloud cred secret")
-		return r.updateSecretAnnotations(secret, InsufficientAnnotation)
+	if _, ok := secret.Data[AzureClientID]; ok {
+		return r.validateAzureCloudCredsSecret(secret)
 	}
 
+	if roleARN, tokenFile, ok := webIdentityShape(secret); ok {
+		return r.validateWebIdentityCredsSecret(secret, roleARN, tokenFile)
+	}
+
+	if missing := utils.MissingRequiredKeys(secret, utils.AWSPlatformKind); len(missing) > 0 {
+		for _, key := range missing {
+			r.logger.Errorf("Couldn't fetch key %s from cloud cred secret", key)
+			errs = multierror.Append(errs, fmt.Errorf("%s: missing key %s", ReasonSecretKeyMissing, key))
+		}
+		return reconcile.Result{}, r.finalizeSecretAnnotations(secret, InsufficientAnnotation, errs)
+	}
+
+	accessKey := secret.Data[AwsAccessKeyName]
+	secretKey := secret.Data[AwsSecretAccessKeyName]
+
+	// aws_session_token is optional, and only present when the creds are
+	// temporary STS creds (AssumeRole/AssumeRoleWithSAML/AssumeRoleWithWebIdentity).
+	sessionToken, isSTS := secret.Data[AwsSessionTokenName]
+
 	infraName, err := utils.LoadInfrastructureName(r.Client, r.logger)
 	if err != nil {
-		return err
+		return reconcile.Result{}, err
 	}
 	creds := credentials.Value{
 		AccessKeyID:     string(accessKey),
 		SecretAccessKey: string(secretKey),
+		SessionToken:    string(sessionToken),
 	}
 	awsClient, err := r.AWSClientBuilder(&creds, infraName)
 	if err != nil {
-		return fmt.Errorf("error creating aws client: %v", err)
+		return reconcile.Result{}, fmt.Errorf("error creating aws client: %v", err)
+	}
+
+	requeueAfter, err := r.expiryRequeueAfter(secret)
+	if err != nil {
+		r.logger.WithError(err).Warning("unable to determine cloud creds expiry")
+	}
+
+	if isSTS {
+		// STS creds are temporary and cannot be used to mint new IAM users,
+		// so only check whether they are sufficient for passthrough.
+		cloudCheckResult, err := utils.CheckCloudCredPassthrough(awsClient, r.logger)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonPassthroughSimulateFailed, err))
+		}
+
+		if cloudCheckResult {
+			r.logger.Info("Verified STS cloud creds can be used as-is (passthrough)")
+			return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, PassthroughSTSAnnotation, errs)
+		}
+
+		r.logger.Warning("STS cloud creds unable to be used for passthrough")
+		return reconcile.Result{}, r.finalizeAndPropagate(secret, InsufficientAnnotation, errs)
 	}
 
 	// Can we mint new creds?
 	cloudCheckResult, err := utils.CheckCloudCredCreation(awsClient, r.logger)
 	if err != nil {
-		r.updateSecretAnnotations(secret, InsufficientAnnotation)
-		return fmt.Errorf("failed checking create cloud creds: %v", err)
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonMintAPIDenied, err))
 	}
 
 	if cloudCheckResult {
 		r.logger.Info("Verified cloud creds can be used for minting new creds")
-		return r.updateSecretAnnotations(secret, MintAnnotation)
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, MintAnnotation, errs)
 	}
 
 	// Else, can we just pass through the current creds?
 	cloudCheckResult, err = utils.CheckCloudCredPassthrough(awsClient, r.logger)
 	if err != nil {
-		r.updateSecretAnnotations(secret, InsufficientAnnotation)
-		return fmt.Errorf("failed checking passthrough cloud creds: %v", err)
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonPassthroughSimulateFailed, err))
 	}
 
 	if cloudCheckResult {
 		r.logger.Info("Verified cloud creds can be used as-is (passthrough)")
-		return r.updateSecretAnnotations(secret, PassthroughAnnotation)
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, PassthroughAnnotation, errs)
 	}
 
 	// Else, these creds aren't presently useful
 	r.logger.Warning("Cloud creds unable to be used for either minting or passthrough")
-	return r.updateSecretAnnotations(secret, InsufficientAnnotation)
+	return reconcile.Result{}, r.finalizeAndPropagate(secret, InsufficientAnnotation, errs)
+}
+
+// resolveAzureCloudName returns the sovereign Azure cloud environment the
+// creds belong to, preferring the secret's own AzureCloudName key and
+// falling back to the cluster's Infrastructure.Status.PlatformStatus.Azure.CloudName.
+// This is what lets a Gov-cloud (or China/Germany cloud) secret validate
+// correctly instead of being probed against the public cloud endpoint and
+// wrongly flagged insufficient.
+func (r *ReconcileCloudCredSecret) resolveAzureCloudName(secret *corev1.Secret) string {
+	if raw, ok := secret.Data[AzureCloudName]; ok && len(raw) > 0 {
+		return string(raw)
+	}
+
+	cloudName, err := utils.LoadInfrastructureAzureCloudName(r.Client, r.logger)
+	if err != nil {
+		r.logger.WithError(err).Warning("unable to determine Azure cloud environment from Infrastructure status; defaulting to AzurePublicCloud")
+		return "AzurePublicCloud"
+	}
+	return cloudName
 }
 
-func (r *ReconcileCloudCredSecret) updateSecretAnnotations(secret *corev1.Secret, value string) error {
+// validateAzureCloudCredsSecret validates an Azure service-principal secret
+// (azure_client_id/azure_client_secret/azure_tenant_id/azure_subscription_id)
+// against the sovereign cloud environment resolved by resolveAzureCloudName.
+func (r *ReconcileCloudCredSecret) validateAzureCloudCredsSecret(secret *corev1.Secret) (reconcile.Result, error) {
+	var errs *multierror.Error
+
+	if missing := utils.MissingRequiredKeys(secret, utils.AzurePlatformKind); len(missing) > 0 {
+		for _, key := range missing {
+			errs = multierror.Append(errs, fmt.Errorf("%s: missing key %s", ReasonSecretKeyMissing, key))
+		}
+		return reconcile.Result{}, r.finalizeSecretAnnotations(secret, InsufficientAnnotation, errs)
+	}
+
+	clientID := secret.Data[AzureClientID]
+	clientSecret := secret.Data[AzureClientSecret]
+	tenantID := secret.Data[AzureTenantID]
+	subscriptionID := secret.Data[AzureSubscriptionID]
+
+	infraName, err := utils.LoadInfrastructureName(r.Client, r.logger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	cloudName := r.resolveAzureCloudName(secret)
+	azureClient, err := r.AzureClientBuilder(string(clientID), string(clientSecret), string(tenantID), string(subscriptionID), cloudName, infraName)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error creating azure client for cloud %q: %v", cloudName, err)
+	}
+
+	// Can we mint new creds?
+	cloudCheckResult, err := ccazure.CheckCloudCredCreation(azureClient, r.logger)
+	if err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonMintAPIDenied, err))
+	}
+
+	if cloudCheckResult {
+		r.logger.Info("Verified Azure cloud creds can be used for minting new creds")
+		return reconcile.Result{}, r.finalizeSecretAnnotations(secret, MintAnnotation, errs)
+	}
+
+	// Else, can we just pass through the current creds?
+	cloudCheckResult, err = ccazure.CheckCloudCredPassthrough(azureClient, r.logger)
+	if err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonPassthroughSimulateFailed, err))
+	}
+
+	if cloudCheckResult {
+		r.logger.Info("Verified Azure cloud creds can be used as-is (passthrough)")
+		return reconcile.Result{}, r.finalizeSecretAnnotations(secret, PassthroughAnnotation, errs)
+	}
+
+	// Else, these creds aren't presently useful
+	r.logger.Warningf("Azure cloud creds (cloud=%s) unable to be used for either minting or passthrough", cloudName)
+	return reconcile.Result{}, r.finalizeAndPropagate(secret, InsufficientAnnotation, errs)
+}
+
+// azureWorkloadIdentityShape reports whether the secret carries an Azure AD
+// Workload Identity shape (azure_client_id + azure_tenant_id +
+// azure_federated_token_file) rather than a static client secret.
+func azureWorkloadIdentityShape(secret *corev1.Secret) (clientID, tenantID, tokenFile string, ok bool) {
+	rawClientID, hasClientID := secret.Data[AzureClientID]
+	rawTenantID, hasTenantID := secret.Data[AzureTenantID]
+	rawTokenFile, hasTokenFile := secret.Data[AzureFederatedTokenFile]
+	if !hasClientID || !hasTenantID || !hasTokenFile {
+		return "", "", "", false
+	}
+	return string(rawClientID), string(rawTenantID), string(rawTokenFile), true
+}
+
+// validateAzureWorkloadIdentityCredsSecret validates creds federated via
+// Azure AD Workload Identity. Minting is never attempted since a federated
+// token cannot create new service principals; only the passthrough
+// permission simulation is run against the federated identity.
+func (r *ReconcileCloudCredSecret) validateAzureWorkloadIdentityCredsSecret(secret *corev1.Secret, clientID, tenantID, tokenFile string) (reconcile.Result, error) {
+	var errs *multierror.Error
+
+	subscriptionID, ok := secret.Data[AzureSubscriptionID]
+	if !ok {
+		errs = multierror.Append(errs, fmt.Errorf("%s: missing key %s", ReasonSecretKeyMissing, AzureSubscriptionID))
+		return reconcile.Result{}, r.finalizeSecretAnnotations(secret, InsufficientAnnotation, errs)
+	}
+
+	infraName, err := utils.LoadInfrastructureName(r.Client, r.logger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	cloudName := r.resolveAzureCloudName(secret)
+	azureClient, err := r.AzureClientBuilderFromWorkloadIdentity(clientID, tenantID, string(subscriptionID), tokenFile, cloudName, infraName)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error creating azure client from workload identity for cloud %q: %v", cloudName, err)
+	}
+
+	requeueAfter := time.Duration(0)
+	if expiry, err := federatedTokenExpiry(tokenFile); err != nil {
+		r.logger.WithError(err).Warning("unable to determine federated token expiry")
+	} else {
+		requeueAfter = requeueAfterOrImmediate(time.Until(expiry))
+		r.recordExpiryAnnotation(secret, expiry)
+	}
+
+	cloudCheckResult, err := ccazure.CheckCloudCredPassthrough(azureClient, r.logger)
+	if err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonPassthroughSimulateFailed, err))
+	}
+
+	if cloudCheckResult {
+		r.logger.Info("Verified Azure workload identity creds can be used as-is (passthrough)")
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, WorkloadIdentityAnnotation, errs)
+	}
+
+	r.logger.Warning("Azure workload identity creds unable to be used for passthrough")
+	return reconcile.Result{}, r.finalizeAndPropagate(secret, InsufficientAnnotation, errs)
+}
+
+// federatedTokenExpiry reads the projected federated token file used by
+// Azure AD Workload Identity and returns the expiry from its "exp" claim, so
+// the secret can be requeued for the moment the token expires. The token is a JWT, but only its unverified payload is
+// decoded here (the token is never used to authenticate this call) since all
+// we need is the expiration the issuing identity already vouched for.
+func federatedTokenExpiry(tokenFile string) (time.Time, error) {
+	raw, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading federated token file: %v", err)
+	}
+
+	parts := strings.Split(strings.TrimSpace(string(raw)), ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("federated token is not a well-formed JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error decoding federated token claims: %v", err)
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("error parsing federated token claims: %v", err)
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, fmt.Errorf("federated token has no exp claim")
+	}
+
+	return time.Unix(claims.Exp, 0), nil
+}
+
+// webIdentityShape reports whether the secret carries an AWS IRSA web-identity
+// shape (role_arn + web_identity_token_file) rather than a static access key pair.
+func webIdentityShape(secret *corev1.Secret) (roleARN, tokenFile string, ok bool) {
+	rawRoleARN, hasRoleARN := secret.Data[AwsRoleARNName]
+	rawTokenFile, hasTokenFile := secret.Data[AwsWebIdentityTokenFileName]
+	if !hasRoleARN || !hasTokenFile {
+		return "", "", false
+	}
+	return string(rawRoleARN), string(rawTokenFile), true
+}
+
+// validateWebIdentityCredsSecret validates creds assumed via AWS IRSA
+// (stscreds.NewWebIdentityRoleProvider). Minting is never attempted since a
+// web-identity session cannot create new IAM users; only the passthrough
+// permission simulation is run against the assumed role.
+func (r *ReconcileCloudCredSecret) validateWebIdentityCredsSecret(secret *corev1.Secret, roleARN, tokenFile string) (reconcile.Result, error) {
+	var errs *multierror.Error
+
+	infraName, err := utils.LoadInfrastructureName(r.Client, r.logger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error creating aws session: %v", err)
+	}
+	provider := stscreds.NewWebIdentityRoleProvider(sts.New(sess), roleARN, "cloud-credential-operator", tokenFile)
+
+	// Retrieve up front (rather than leaving it to the first AWS API call) so
+	// that the assumed-role session's own expiration, not an unrelated
+	// aws_credentials_expiry secret field, drives the requeue.
+	if _, err := provider.Retrieve(); err != nil {
+		return reconcile.Result{}, fmt.Errorf("error retrieving web identity creds: %v", err)
+	}
+	requeueAfter := requeueAfterOrImmediate(time.Until(provider.ExpiresAt()))
+	r.recordExpiryAnnotation(secret, provider.ExpiresAt())
+
+	awsClient, err := r.AWSClientBuilderFromProvider(provider, infraName)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error creating aws client from web identity provider: %v", err)
+	}
+
+	cloudCheckResult, err := utils.CheckCloudCredPassthrough(awsClient, r.logger)
+	if err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", ReasonPassthroughSimulateFailed, err))
+	}
+
+	if cloudCheckResult {
+		r.logger.Info("Verified web identity creds can be used as-is (passthrough)")
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.finalizeSecretAnnotations(secret, WorkloadIdentityAnnotation, errs)
+	}
+
+	r.logger.Warning("Web identity creds unable to be used for passthrough")
+	return reconcile.Result{}, r.finalizeAndPropagate(secret, InsufficientAnnotation, errs)
+}
+
+// expiryRequeueAfter reads the optional aws_credentials_expiry field from the
+// secret, records it as an annotation so other controllers can observe it,
+// and returns the duration until that expiry so the caller can requeue the
+// Reconcile for the moment the creds expire.
+func (r *ReconcileCloudCredSecret) expiryRequeueAfter(secret *corev1.Secret) (time.Duration, error) {
+	rawExpiry, ok := secret.Data[AwsCredsExpiryName]
+	if !ok {
+		return 0, nil
+	}
+
+	expiry, err := time.Parse(time.RFC3339, string(rawExpiry))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q: %v", AwsCredsExpiryName, string(rawExpiry), err)
+	}
+
+	r.recordExpiryAnnotation(secret, expiry)
+	return requeueAfterOrImmediate(time.Until(expiry)), nil
+}
+
+// recordExpiryAnnotation sets ExpiryAnnotation on secret to expiry, so other
+// controllers can observe the cloud creds' known expiration regardless of
+// which validation path (static expiry field, assumed-role session,
+// federated token) derived it.
+func (r *ReconcileCloudCredSecret) recordExpiryAnnotation(secret *corev1.Secret, expiry time.Time) {
+	annotations := secret.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ExpiryAnnotation] = expiry.UTC().Format(time.RFC3339)
+	secret.SetAnnotations(annotations)
+}
+
+// requeueAfterOrImmediate clamps d to a minimal positive duration when it is
+// zero or negative. controller-runtime's workqueue only schedules a delayed
+// AddAfter when RequeueAfter > 0, so creds whose expiry is already in the
+// past must still get a positive value here, or the expiry-driven reconcile
+// never fires.
+func requeueAfterOrImmediate(d time.Duration) time.Duration {
+	if d <= 0 {
+		return time.Second
+	}
+	return d
+}
+
+// finalizeSecretAnnotations sets the mode annotation, along with the
+// aggregated validation errors (if any) and the time of this validation
+// attempt, then persists the secret in a single update.
+func (r *ReconcileCloudCredSecret) finalizeSecretAnnotations(secret *corev1.Secret, mode string, errs *multierror.Error) error {
 	secretAnnotations := secret.GetAnnotations()
 	if secretAnnotations == nil {
 		secretAnnotations = map[string]string{}
 	}
 
-	secretAnnotations[AnnotationKey] = value
+	secretAnnotations[AnnotationKey] = mode
+	if err := errs.ErrorOrNil(); err != nil {
+		secretAnnotations[LastValidationErrorsAnnotation] = err.Error()
+	} else {
+		delete(secretAnnotations, LastValidationErrorsAnnotation)
+	}
+	secretAnnotations[LastValidationTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
 	secret.SetAnnotations(secretAnnotations)
 
 	return r.Update(context.Background(), secret)
 }
+
+// finalizeAndPropagate behaves like finalizeSecretAnnotations, but also
+// surfaces any aggregated check errors back to the caller once the secret has
+// been annotated, so that Reconcile returns a real error and controller-runtime
+// requeues with exponential backoff. Without this, a transient failure (e.g.
+// throttling during the mint/passthrough probes) would be recorded in the
+// last-validation-errors annotation and then forgotten until an unrelated
+// secret-update event happened to refire the watch.
+func (r *ReconcileCloudCredSecret) finalizeAndPropagate(secret *corev1.Secret, mode string, errs *multierror.Error) error {
+	if err := r.finalizeSecretAnnotations(secret, mode, errs); err != nil {
+		return err
+	}
+	return errs.ErrorOrNil()
+}
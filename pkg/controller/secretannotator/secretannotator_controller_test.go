@@ -0,0 +1,222 @@
+/*
+Copyright 2018 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretannotator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-creds",
+			Namespace: "kube-system",
+		},
+		Data: map[string][]byte{},
+	}
+}
+
+func newTestReconciler(secret *corev1.Secret) *ReconcileCloudCredSecret {
+	return &ReconcileCloudCredSecret{
+		Client: fake.NewFakeClient(secret),
+		logger: log.WithField("controller", "secretannotator-test"),
+	}
+}
+
+func newTestReconcilerWithInfra(secret *corev1.Secret, infra *configv1.Infrastructure) *ReconcileCloudCredSecret {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := configv1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	return &ReconcileCloudCredSecret{
+		Client: fake.NewFakeClientWithScheme(scheme, secret, infra),
+		logger: log.WithField("controller", "secretannotator-test"),
+	}
+}
+
+// finalizeAndPropagate must still surface an aggregated check error after
+// successfully annotating the secret, otherwise Reconcile returns nil and
+// controller-runtime never requeues a transient failure (e.g. throttling)
+// with exponential backoff.
+func TestFinalizeAndPropagatePropagatesAggregatedErrors(t *testing.T) {
+	secret := testSecret()
+	r := newTestReconciler(secret)
+
+	errs := multierror.Append(nil, assert.AnError)
+
+	err := r.finalizeAndPropagate(secret, InsufficientAnnotation, errs)
+	require.Error(t, err)
+	assert.Equal(t, InsufficientAnnotation, secret.GetAnnotations()[AnnotationKey])
+	assert.NotEmpty(t, secret.GetAnnotations()[LastValidationErrorsAnnotation])
+}
+
+// finalizeAndPropagate must not fabricate an error when nothing went wrong.
+func TestFinalizeAndPropagateNoErrors(t *testing.T) {
+	secret := testSecret()
+	r := newTestReconciler(secret)
+
+	err := r.finalizeAndPropagate(secret, MintAnnotation, nil)
+	require.NoError(t, err)
+	assert.Equal(t, MintAnnotation, secret.GetAnnotations()[AnnotationKey])
+	assert.Empty(t, secret.GetAnnotations()[LastValidationErrorsAnnotation])
+}
+
+// finalizeSecretAnnotations alone (the plain, non-propagating path used by
+// the missing-key branches) must never turn aggregated errors into a
+// returned error; only the Update call's own error should surface.
+func TestFinalizeSecretAnnotationsDoesNotPropagate(t *testing.T) {
+	secret := testSecret()
+	r := newTestReconciler(secret)
+
+	errs := multierror.Append(nil, assert.AnError)
+
+	err := r.finalizeSecretAnnotations(secret, InsufficientAnnotation, errs)
+	require.NoError(t, err)
+	assert.Equal(t, InsufficientAnnotation, secret.GetAnnotations()[AnnotationKey])
+	assert.NotEmpty(t, secret.GetAnnotations()[LastValidationErrorsAnnotation])
+}
+
+func TestValidateCloudCredsSecretMissingAWSKeys(t *testing.T) {
+	secret := testSecret()
+	r := newTestReconciler(secret)
+
+	_, err := r.validateCloudCredsSecret(secret)
+	require.NoError(t, err)
+	assert.Equal(t, InsufficientAnnotation, secret.GetAnnotations()[AnnotationKey])
+}
+
+func TestWebIdentityShape(t *testing.T) {
+	secret := testSecret()
+	_, _, ok := webIdentityShape(secret)
+	assert.False(t, ok, "secret with no role_arn/web_identity_token_file should not be detected as web-identity shaped")
+
+	secret.Data[AwsRoleARNName] = []byte("arn:aws:iam::123456789012:role/test")
+	secret.Data[AwsWebIdentityTokenFileName] = []byte("/var/run/secrets/token")
+	roleARN, tokenFile, ok := webIdentityShape(secret)
+	require.True(t, ok)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/test", roleARN)
+	assert.Equal(t, "/var/run/secrets/token", tokenFile)
+}
+
+func TestAzureWorkloadIdentityShape(t *testing.T) {
+	secret := testSecret()
+	_, _, _, ok := azureWorkloadIdentityShape(secret)
+	assert.False(t, ok, "secret with no azure_federated_token_file should not be detected as workload-identity shaped")
+
+	secret.Data[AzureClientID] = []byte("11111111-1111-1111-1111-111111111111")
+	secret.Data[AzureTenantID] = []byte("22222222-2222-2222-2222-222222222222")
+	secret.Data[AzureFederatedTokenFile] = []byte("/var/run/secrets/azure/tokens/azure-identity-token")
+	clientID, tenantID, tokenFile, ok := azureWorkloadIdentityShape(secret)
+	require.True(t, ok)
+	assert.Equal(t, "11111111-1111-1111-1111-111111111111", clientID)
+	assert.Equal(t, "22222222-2222-2222-2222-222222222222", tenantID)
+	assert.Equal(t, "/var/run/secrets/azure/tokens/azure-identity-token", tokenFile)
+
+	// A client secret present alongside the federated token file does not
+	// change the detected shape: the federated token takes precedence since
+	// validateCloudCredsSecret checks azureWorkloadIdentityShape first.
+	secret.Data[AzureClientSecret] = []byte("some-secret")
+	_, _, _, ok = azureWorkloadIdentityShape(secret)
+	assert.True(t, ok)
+}
+
+// resolveAzureCloudName must prefer the secret's own AzureCloudName key over
+// the cluster's Infrastructure status, so a Gov-cloud secret is not probed
+// against the wrong endpoint just because the cluster itself runs elsewhere.
+func TestResolveAzureCloudNamePrefersSecretKey(t *testing.T) {
+	secret := testSecret()
+	secret.Data[AzureCloudName] = []byte("AzureUSGovernmentCloud")
+
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus: &configv1.PlatformStatus{
+				Type:  "Azure",
+				Azure: &configv1.AzurePlatformStatus{CloudName: "AzurePublicCloud"},
+			},
+		},
+	}
+	r := newTestReconcilerWithInfra(secret, infra)
+
+	assert.Equal(t, "AzureUSGovernmentCloud", r.resolveAzureCloudName(secret))
+}
+
+// resolveAzureCloudName must fall back to the cluster's Infrastructure status
+// when the secret carries no AzureCloudName key of its own.
+func TestResolveAzureCloudNameFallsBackToInfrastructure(t *testing.T) {
+	secret := testSecret()
+
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus: &configv1.PlatformStatus{
+				Type:  "Azure",
+				Azure: &configv1.AzurePlatformStatus{CloudName: "AzureGermanCloud"},
+			},
+		},
+	}
+	r := newTestReconcilerWithInfra(secret, infra)
+
+	assert.Equal(t, "AzureGermanCloud", r.resolveAzureCloudName(secret))
+}
+
+// resolveAzureCloudName must default to the public cloud when neither the
+// secret nor the Infrastructure status can supply a cloud name, rather than
+// propagating the lookup error to the caller.
+func TestResolveAzureCloudNameDefaultsToPublicCloud(t *testing.T) {
+	secret := testSecret()
+
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.InfrastructureStatus{},
+	}
+	r := newTestReconcilerWithInfra(secret, infra)
+
+	assert.Equal(t, "AzurePublicCloud", r.resolveAzureCloudName(secret))
+}
+
+// requeueAfterOrImmediate must clamp a zero or negative duration (an expiry
+// already in the past) to a minimal positive duration, since
+// controller-runtime's workqueue only schedules a delayed AddAfter when
+// RequeueAfter > 0 — an exact 0 would silently disable requeueing.
+func TestRequeueAfterOrImmediateClampsNonPositive(t *testing.T) {
+	assert.Equal(t, time.Second, requeueAfterOrImmediate(0))
+	assert.Equal(t, time.Second, requeueAfterOrImmediate(-time.Hour))
+}
+
+// requeueAfterOrImmediate must pass a genuinely positive duration through
+// unchanged.
+func TestRequeueAfterOrImmediatePassesThroughPositive(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, requeueAfterOrImmediate(5*time.Minute))
+}
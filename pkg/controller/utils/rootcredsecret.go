@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PlatformKind identifies the cloud platform a cluster is running on, matching
+// the values found in configv1.Infrastructure.Status.PlatformStatus.Type.
+type PlatformKind string
+
+const (
+	AWSPlatformKind          PlatformKind = "AWS"
+	AzurePlatformKind        PlatformKind = "Azure"
+	GCPPlatformKind          PlatformKind = "GCP"
+	VSpherePlatformKind      PlatformKind = "VSphere"
+	OpenStackPlatformKind    PlatformKind = "OpenStack"
+	IBMCloudPlatformKind     PlatformKind = "IBMCloud"
+	AlibabaCloudPlatformKind PlatformKind = "AlibabaCloud"
+)
+
+// RootCredSecretSpec describes where a platform's root cloud credential
+// secret lives, and which keys are expected to be present in its Data.
+// RequiredKeys drives the shared MissingRequiredKeys precondition check, so
+// an annotator's key-presence validation for a platform doesn't need its own
+// hand-written if/else chain. It does not (yet) decouple the platform's
+// mint/passthrough capability probing from the annotator core: that still
+// requires each platform's own cloud SDK client and is wired directly into
+// secretannotator's validate*CredsSecret functions.
+type RootCredSecretSpec struct {
+	NamespacedName types.NamespacedName
+	RequiredKeys   []string
+}
+
+// rootCredSecretRegistry maps a platform kind to its root cred secret spec.
+// New platforms register their shape via RegisterRootCredSecret without
+// needing to modify ResolveRootCredSecret itself, mirroring the way
+// GetCredentialsRequestCloudType dispatches on ProviderSpec.Kind.
+var rootCredSecretRegistry = map[PlatformKind]RootCredSecretSpec{}
+
+// RegisterRootCredSecret registers the root cred secret location and expected
+// keys for a given platform. It is expected to be called from package init()
+// functions, one per supported platform.
+func RegisterRootCredSecret(kind PlatformKind, spec RootCredSecretSpec) {
+	rootCredSecretRegistry[kind] = spec
+}
+
+func init() {
+	RegisterRootCredSecret(AWSPlatformKind, RootCredSecretSpec{
+		NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "aws-creds"},
+		RequiredKeys:   []string{"aws_access_key_id", "aws_secret_access_key"},
+	})
+	RegisterRootCredSecret(AzurePlatformKind, RootCredSecretSpec{
+		NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "azure-credentials"},
+		RequiredKeys:   []string{"azure_client_id", "azure_client_secret", "azure_subscription_id", "azure_tenant_id"},
+	})
+	RegisterRootCredSecret(GCPPlatformKind, RootCredSecretSpec{
+		NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "gcp-credentials"},
+		RequiredKeys:   []string{"service_account.json"},
+	})
+	RegisterRootCredSecret(VSpherePlatformKind, RootCredSecretSpec{
+		NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "vsphere-creds"},
+		RequiredKeys:   []string{"username", "password"},
+	})
+	RegisterRootCredSecret(OpenStackPlatformKind, RootCredSecretSpec{
+		NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "openstack-credentials"},
+		RequiredKeys:   []string{"clouds.yaml"},
+	})
+	RegisterRootCredSecret(IBMCloudPlatformKind, RootCredSecretSpec{
+		NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "ibmcloud-credentials"},
+		RequiredKeys:   []string{"ibmcloud_api_key"},
+	})
+	RegisterRootCredSecret(AlibabaCloudPlatformKind, RootCredSecretSpec{
+		NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "alibabacloud-credentials"},
+		RequiredKeys:   []string{"access_key_id", "access_key_secret"},
+	})
+}
+
+// RequireSupportedPlatform returns an error unless kind is one of supported.
+// Each secret annotator's Add() calls this right after resolving the root
+// cred secret, before it ever registers a watch, so a reconciler that only
+// knows how to validate a subset of platforms never starts watching (and
+// misreporting the status of) a root secret it cannot actually validate.
+func RequireSupportedPlatform(kind PlatformKind, supported ...PlatformKind) error {
+	for _, k := range supported {
+		if kind == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("platform %q is not supported by this secret annotator", kind)
+}
+
+// RequiredKeysFor returns the RequiredKeys registered for kind, and whether
+// kind is registered at all.
+func RequiredKeysFor(kind PlatformKind) ([]string, bool) {
+	spec, ok := rootCredSecretRegistry[kind]
+	if !ok {
+		return nil, false
+	}
+	return spec.RequiredKeys, true
+}
+
+// MissingRequiredKeys returns the subset of kind's registered RequiredKeys
+// that are absent from secret.Data, so an annotator can surface a precise
+// SecretKeyMissing error for a platform without hand-coding that platform's
+// key list a second time.
+func MissingRequiredKeys(secret *corev1.Secret, kind PlatformKind) []string {
+	keys, ok := RequiredKeysFor(kind)
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if _, ok := secret.Data[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// ResolveRootCredSecret reads the cluster's Infrastructure config and returns
+// the namespaced name of the root cloud credential secret for the detected
+// platform, along with the platform kind itself. This replaces hard-coding
+// the secret name/namespace in each secret annotator, so a single binary can
+// annotate whichever root cred secret matches the detected platform.
+func ResolveRootCredSecret(c client.Client) (types.NamespacedName, PlatformKind, error) {
+	infra := &configv1.Infrastructure{}
+	err := c.Get(context.Background(), types.NamespacedName{Name: "cluster"}, infra)
+	if err != nil {
+		return types.NamespacedName{}, "", fmt.Errorf("error loading Infrastructure config 'cluster': %v", err)
+	}
+
+	if infra.Status.PlatformStatus == nil {
+		return types.NamespacedName{}, "", fmt.Errorf("infrastructure config has no platform status")
+	}
+
+	kind := PlatformKind(infra.Status.PlatformStatus.Type)
+	spec, ok := rootCredSecretRegistry[kind]
+	if !ok {
+		return types.NamespacedName{}, kind, fmt.Errorf("no root cred secret registered for platform %q", kind)
+	}
+
+	return spec.NamespacedName, kind, nil
+}
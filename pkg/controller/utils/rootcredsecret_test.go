@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func infraFixture(platformType configv1.PlatformType, azureCloudName configv1.AzureCloudEnvironment) *configv1.Infrastructure {
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.InfrastructureStatus{
+			InfrastructureName: "test-infra-1234",
+		},
+	}
+	if platformType != "" {
+		infra.Status.PlatformStatus = &configv1.PlatformStatus{Type: platformType}
+		if azureCloudName != "" {
+			infra.Status.PlatformStatus.Azure = &configv1.AzurePlatformStatus{CloudName: azureCloudName}
+		}
+	}
+	return infra
+}
+
+func fakeSchemeClient(objs ...runtime.Object) client.Client {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := configv1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	return fake.NewFakeClientWithScheme(scheme, objs...)
+}
+
+func TestResolveRootCredSecret(t *testing.T) {
+	tests := []struct {
+		name         string
+		infra        *configv1.Infrastructure
+		expectedName types.NamespacedName
+		expectedKind PlatformKind
+		wantErr      bool
+	}{
+		{
+			name:         "AWS",
+			infra:        infraFixture("AWS", ""),
+			expectedName: types.NamespacedName{Namespace: "kube-system", Name: "aws-creds"},
+			expectedKind: AWSPlatformKind,
+		},
+		{
+			name:         "Azure",
+			infra:        infraFixture("Azure", ""),
+			expectedName: types.NamespacedName{Namespace: "kube-system", Name: "azure-credentials"},
+			expectedKind: AzurePlatformKind,
+		},
+		{
+			name:    "unrecognized platform",
+			infra:   infraFixture("NotAPlatform", ""),
+			wantErr: true,
+		},
+		{
+			name:    "no platform status",
+			infra:   infraFixture("", ""),
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := fakeSchemeClient(test.infra)
+
+			name, kind, err := ResolveRootCredSecret(c)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedName, name)
+			assert.Equal(t, test.expectedKind, kind)
+		})
+	}
+}
+
+func TestResolveRootCredSecretNoInfrastructure(t *testing.T) {
+	c := fakeSchemeClient()
+	_, _, err := ResolveRootCredSecret(c)
+	assert.Error(t, err)
+}
+
+func TestRequireSupportedPlatform(t *testing.T) {
+	assert.NoError(t, RequireSupportedPlatform(AWSPlatformKind, AWSPlatformKind, AzurePlatformKind))
+	assert.Error(t, RequireSupportedPlatform(GCPPlatformKind, AWSPlatformKind, AzurePlatformKind))
+}
+
+func TestLoadInfrastructureAzureCloudName(t *testing.T) {
+	tests := []struct {
+		name      string
+		infra     *configv1.Infrastructure
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "gov cloud",
+			infra:    infraFixture("Azure", "AzureUSGovernmentCloud"),
+			expected: "AzureUSGovernmentCloud",
+		},
+		{
+			name:      "no azure platform status",
+			infra:     infraFixture("AWS", ""),
+			expectErr: true,
+		},
+		{
+			name:      "no platform status at all",
+			infra:     infraFixture("", ""),
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := fakeSchemeClient(test.infra)
+			logger := log.WithField("test", test.name)
+
+			cloudName, err := LoadInfrastructureAzureCloudName(c, logger)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, cloudName)
+		})
+	}
+}
+
+func TestRequiredKeysForAndMissingRequiredKeys(t *testing.T) {
+	keys, ok := RequiredKeysFor(AWSPlatformKind)
+	require.True(t, ok)
+	assert.ElementsMatch(t, []string{"aws_access_key_id", "aws_secret_access_key"}, keys)
+
+	_, ok = RequiredKeysFor(PlatformKind("NotAPlatform"))
+	assert.False(t, ok)
+
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"aws_access_key_id": []byte("id"),
+	}}
+	missing := MissingRequiredKeys(secret, AWSPlatformKind)
+	assert.Equal(t, []string{"aws_secret_access_key"}, missing)
+
+	complete := &corev1.Secret{Data: map[string][]byte{
+		"aws_access_key_id":     []byte("id"),
+		"aws_secret_access_key": []byte("secret"),
+	}}
+	assert.Empty(t, MissingRequiredKeys(complete, AWSPlatformKind))
+
+	assert.Nil(t, MissingRequiredKeys(secret, PlatformKind("NotAPlatform")))
+}
@@ -20,8 +20,9 @@ import (
 )
 
 const (
-	awsCredsSecretIDKey     = "aws_access_key_id"
-	awsCredsSecretAccessKey = "aws_secret_access_key"
+	awsCredsSecretIDKey        = "aws_access_key_id"
+	awsCredsSecretAccessKey    = "aws_secret_access_key"
+	awsCredsSecretSessionToken = "aws_session_token"
 )
 
 func LoadCredsFromSecret(kubeClient client.Client, namespace, secretName string) (*credentials.Value, error) {
@@ -46,9 +47,13 @@ func LoadCredsFromSecret(kubeClient client.Client, namespace, secretName string)
 		return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v",
 			secretName, awsCredsSecretAccessKey)
 	}
+	// aws_session_token is optional, and only present when the creds are
+	// temporary STS creds (AssumeRole/AssumeRoleWithSAML/AssumeRoleWithWebIdentity).
+	sessionToken := secret.Data[awsCredsSecretSessionToken]
 	creds := &credentials.Value{
 		AccessKeyID:     string(accessKeyID),
 		SecretAccessKey: string(secretAccessKey),
+		SessionToken:    string(sessionToken),
 	}
 	return creds, nil
 }
@@ -68,6 +73,27 @@ func LoadInfrastructureName(c client.Client, logger log.FieldLogger) (string, er
 
 }
 
+// LoadInfrastructureAzureCloudName loads the cluster Infrastructure config and returns the
+// Azure cloud environment name (e.g. AzurePublicCloud, AzureUSGovernmentCloud, AzureChinaCloud,
+// AzureGermanCloud) that the cluster was installed into. This is used as a fallback when the
+// cloud cred secret itself does not specify which Azure cloud environment to target.
+func LoadInfrastructureAzureCloudName(c client.Client, logger log.FieldLogger) (string, error) {
+	infra := &configv1.Infrastructure{}
+	err := c.Get(context.Background(), types.NamespacedName{Name: "cluster"}, infra)
+	if err != nil {
+		logger.WithError(err).Error("error loading Infrastructure config 'cluster'")
+		return "", err
+	}
+
+	if infra.Status.PlatformStatus == nil || infra.Status.PlatformStatus.Azure == nil {
+		return "", fmt.Errorf("infrastructure config has no Azure platform status")
+	}
+
+	cloudName := string(infra.Status.PlatformStatus.Azure.CloudName)
+	logger.Debugf("Loaded Azure cloud name: %s", cloudName)
+	return cloudName, nil
+}
+
 // GetCredentialsRequestCloudType decodes a Spec.ProviderSpec and returns the kind
 // field.
 func GetCredentialsRequestCloudType(providerSpec *runtime.RawExtension) (string, error) {